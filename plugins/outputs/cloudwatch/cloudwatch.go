@@ -2,32 +2,149 @@ package cloudwatch
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"math"
+	"os"
 	"sort"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"golang.org/x/time/rate"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
 	internalaws "github.com/influxdata/telegraf/config/aws"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
+// cloudWatchAPI is the subset of *cloudwatch.Client used by this plugin,
+// narrowed so tests can substitute a fake without a real AWS connection.
+type cloudWatchAPI interface {
+	PutMetricData(ctx context.Context, params *cloudwatch.PutMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error)
+}
+
 type CloudWatch struct {
 	Namespace             string `toml:"namespace"` // CloudWatch Metrics Namespace
 	HighResolutionMetrics bool   `toml:"high_resolution_metrics"`
-	svc                   *cloudwatch.Client
+	svc                   cloudWatchAPI
+
+	// Mode selects the transport: "putmetricdata" (default) calls the
+	// CloudWatch PutMetricData API; "emf" serializes each metric as
+	// CloudWatch Embedded Metric Format JSON instead.
+	Mode string `toml:"mode"`
+	// LogGroup and LogStream send EMF JSON via CloudWatch Logs PutLogEvents
+	// instead of stdout. Both are required together.
+	LogGroup      string `toml:"log_group"`
+	LogStream     string `toml:"log_stream"`
+	logsSvc       *cloudwatchlogs.Client
+	sequenceToken *string
+
+	// MaxConcurrentRequests bounds the worker pool that dispatches partitions
+	// to PutMetricData concurrently.
+	MaxConcurrentRequests int `toml:"max_concurrent_requests"`
+	// MaxRetries and RetryMaxBackoff configure the AWS SDK retryer used for
+	// throttled or transient PutMetricData failures.
+	MaxRetries      int             `toml:"max_retries"`
+	RetryMaxBackoff config.Duration `toml:"retry_max_backoff"`
+	// RateLimit caps PutMetricData calls/sec; 0 disables rate limiting.
+	RateLimit float64 `toml:"rate_limit"`
+	limiter   *rate.Limiter
 
 	WriteStatistics bool `toml:"write_statistics"`
 
+	// WriteDistributions sends fields with a "_histogram" suffix as CloudWatch
+	// distribution metrics (Values/Counts) instead of a single Value.
+	WriteDistributions bool `toml:"write_distributions"`
+
+	// UnitTag is the name of a tag whose value is used verbatim as the
+	// CloudWatch StandardUnit for a metric. Takes precedence over Unit.
+	UnitTag string `toml:"unit_tag"`
+	// Unit maps field names (with any "_histogram", "_min", "_max", "_sum" or
+	// "_count" suffix stripped) to a CloudWatch StandardUnit, used when UnitTag
+	// is unset or not present. Neither is applied to statistic sets built by
+	// WriteStatistics.
+	Unit map[string]string `toml:"unit"`
+
+	// DimensionsInclude is an allowlist of tag names, in priority order, used
+	// to pick dimensions instead of the first 10 tags alphabetically.
+	DimensionsInclude []string `toml:"dimensions_include"`
+	// DimensionsExclude is a denylist of tag names that are never turned into
+	// dimensions, regardless of DimensionsInclude.
+	DimensionsExclude []string `toml:"dimensions_exclude"`
+	// DimensionsDefaultHost controls whether the "host" tag is always
+	// included as a dimension. Defaults to true; set to false to make "host"
+	// subject to the same include/exclude rules as any other tag.
+	DimensionsDefaultHost *bool `toml:"dimensions_default_host"`
+
+	// Metrics is a list of per-measurement overrides, consulted in order
+	// (first match wins), letting a single plugin instance route different
+	// measurements to different namespaces with stable, templated names.
+	Metrics []*MetricConfig `toml:"metric"`
+
 	Log telegraf.Logger `toml:"-"`
 
 	internalaws.CredentialConfig
 }
 
+// MetricConfig overrides the namespace, metric name, unit and storage
+// resolution used for measurements matching Measurement, a glob pattern.
+type MetricConfig struct {
+	Measurement       string `toml:"measurement"`
+	Namespace         string `toml:"namespace"`
+	NameTemplate      string `toml:"name_template"`
+	Unit              string `toml:"unit"`
+	StorageResolution int64  `toml:"storage_resolution"`
+
+	filter filter.Filter
+	tmpl   *template.Template
+}
+
+func (m *MetricConfig) matches(measurement string) bool {
+	return m.filter != nil && m.filter.Match(measurement)
+}
+
+// nameTemplateData is the data passed to a MetricConfig's NameTemplate.
+type nameTemplateData struct {
+	Measurement string
+	Field       string
+	Tags        map[string]string
+}
+
+// Init precompiles the glob filters and name templates of Metrics, so
+// malformed configuration is rejected before the first Write.
+func (c *CloudWatch) Init() error {
+	for _, m := range c.Metrics {
+		f, err := filter.Compile([]string{m.Measurement})
+		if err != nil {
+			return fmt.Errorf("cloudwatch: compiling filter for metric %q: %w", m.Measurement, err)
+		}
+		m.filter = f
+
+		if m.NameTemplate == "" {
+			continue
+		}
+
+		tmpl, err := template.New(m.Measurement).Parse(m.NameTemplate)
+		if err != nil {
+			return fmt.Errorf("cloudwatch: parsing name_template for metric %q: %w", m.Measurement, err)
+		}
+		m.tmpl = tmpl
+	}
+
+	return nil
+}
+
 type statisticType int
 
 const (
@@ -36,6 +153,7 @@ const (
 	statisticTypeMin
 	statisticTypeSum
 	statisticTypeCount
+	statisticTypeDistribution
 )
 
 type cloudwatchField interface {
@@ -43,6 +161,116 @@ type cloudwatchField interface {
 	buildDatum() []types.MetricDatum
 }
 
+// dimensionsConfig controls how BuildDimensions picks tags to turn into
+// CloudWatch dimensions.
+type dimensionsConfig struct {
+	include     []string
+	exclude     map[string]bool
+	defaultHost bool
+	log         telegraf.Logger
+}
+
+// metricDatumOptions bundles the plugin configuration needed while turning a
+// telegraf.Metric (or a batch of them, for distributions) into MetricDatums.
+type metricDatumOptions struct {
+	buildStatistic    bool
+	buildDistribution bool
+	highResolution    bool
+	unitTag           string
+	units             map[string]string
+	dimensions        *dimensionsConfig
+	namespace         string
+	metricRules       []*MetricConfig
+}
+
+func (c *CloudWatch) metricDatumOptions() metricDatumOptions {
+	exclude := make(map[string]bool, len(c.DimensionsExclude))
+	for _, k := range c.DimensionsExclude {
+		exclude[k] = true
+	}
+
+	defaultHost := true
+	if c.DimensionsDefaultHost != nil {
+		defaultHost = *c.DimensionsDefaultHost
+	}
+
+	return metricDatumOptions{
+		buildStatistic:    c.WriteStatistics,
+		buildDistribution: c.WriteDistributions,
+		highResolution:    c.HighResolutionMetrics,
+		unitTag:           c.UnitTag,
+		units:             c.Unit,
+		dimensions: &dimensionsConfig{
+			include:     c.DimensionsInclude,
+			exclude:     exclude,
+			defaultHost: defaultHost,
+			log:         c.Log,
+		},
+		namespace:   c.Namespace,
+		metricRules: c.Metrics,
+	}
+}
+
+// ruleFor returns the first MetricConfig whose Measurement glob matches, or
+// nil if no rule applies and default behavior should be used.
+func (o metricDatumOptions) ruleFor(measurement string) *MetricConfig {
+	for _, m := range o.metricRules {
+		if m.matches(measurement) {
+			return m
+		}
+	}
+	return nil
+}
+
+// namespaceFor resolves the CloudWatch namespace a measurement should be
+// written to, honoring a matching rule's Namespace override.
+func (o metricDatumOptions) namespaceFor(rule *MetricConfig) string {
+	if rule != nil && rule.Namespace != "" {
+		return rule.Namespace
+	}
+	return o.namespace
+}
+
+// storageResolutionFor resolves the datum storage resolution, honoring a
+// matching rule's StorageResolution override.
+func (o metricDatumOptions) storageResolutionFor(rule *MetricConfig) int64 {
+	if rule != nil && rule.StorageResolution != 0 {
+		return rule.StorageResolution
+	}
+	if o.highResolution {
+		return 1
+	}
+	return 60
+}
+
+// unitFor resolves the CloudWatch StandardUnit for a field, honoring a
+// matching rule's Unit override ahead of unit_tag/the units table.
+func (o metricDatumOptions) unitFor(rule *MetricConfig, fieldName string, tags map[string]string) types.StandardUnit {
+	if rule != nil && rule.Unit != "" {
+		return types.StandardUnit(rule.Unit)
+	}
+	return fieldUnit(o.unitTag, o.units, fieldName, tags)
+}
+
+// metricNameFor renders a matching rule's NameTemplate, if any, falling back
+// to the empty string so callers know to use their own default naming.
+func (o metricDatumOptions) metricNameFor(rule *MetricConfig, measurement, fieldName string, tags map[string]string) string {
+	if rule == nil || rule.tmpl == nil {
+		return ""
+	}
+
+	var buf strings.Builder
+	if err := rule.tmpl.Execute(&buf, nameTemplateData{
+		Measurement: measurement,
+		Field:       fieldName,
+		Tags:        tags,
+	}); err != nil {
+		return ""
+	}
+
+	return buf.String()
+}
+
 type statisticField struct {
 	metricName        string
 	fieldName         string
@@ -50,6 +278,10 @@ type statisticField struct {
 	values            map[statisticType]float64
 	timestamp         time.Time
 	storageResolution int64
+	dimCfg            *dimensionsConfig
+	// nameOverride, if set (e.g. from a [[metric]] name_template), replaces
+	// the default "measurement_field" metric name.
+	nameOverride string
 }
 
 func (f *statisticField) addValue(sType statisticType, value float64) {
@@ -61,6 +293,11 @@ func (f *statisticField) addValue(sType statisticType, value float64) {
 func (f *statisticField) buildDatum() []types.MetricDatum {
 	var datums []types.MetricDatum
 
+	baseName := f.nameOverride
+	if baseName == "" {
+		baseName = strings.Join([]string{f.metricName, f.fieldName}, "_")
+	}
+
 	if f.hasAllFields() {
 		// If we have all required fields, we build datum with StatisticValues
 		min := f.values[statisticTypeMin]
@@ -69,8 +306,8 @@ func (f *statisticField) buildDatum() []types.MetricDatum {
 		count := f.values[statisticTypeCount]
 
 		datum := types.MetricDatum{
-			MetricName: aws.String(strings.Join([]string{f.metricName, f.fieldName}, "_")),
-			Dimensions: BuildDimensions(f.tags),
+			MetricName: aws.String(baseName),
+			Dimensions: BuildDimensions(f.tags, f.dimCfg),
 			Timestamp:  aws.Time(f.timestamp),
 			StatisticValues: &types.StatisticSet{
 				Minimum:     aws.Float64(min),
@@ -86,20 +323,21 @@ func (f *statisticField) buildDatum() []types.MetricDatum {
 		// If we don't have all required fields, we build each field as independent datum
 		for sType, value := range f.values {
 			datum := types.MetricDatum{
-				Value:      aws.Float64(value),
-				Dimensions: BuildDimensions(f.tags),
-				Timestamp:  aws.Time(f.timestamp),
+				Value:             aws.Float64(value),
+				Dimensions:        BuildDimensions(f.tags, f.dimCfg),
+				Timestamp:         aws.Time(f.timestamp),
+				StorageResolution: aws.Int32(int32(f.storageResolution)),
 			}
 
 			switch sType {
 			case statisticTypeMin:
-				datum.MetricName = aws.String(strings.Join([]string{f.metricName, f.fieldName, "min"}, "_"))
+				datum.MetricName = aws.String(strings.Join([]string{baseName, "min"}, "_"))
 			case statisticTypeMax:
-				datum.MetricName = aws.String(strings.Join([]string{f.metricName, f.fieldName, "max"}, "_"))
+				datum.MetricName = aws.String(strings.Join([]string{baseName, "max"}, "_"))
 			case statisticTypeSum:
-				datum.MetricName = aws.String(strings.Join([]string{f.metricName, f.fieldName, "sum"}, "_"))
+				datum.MetricName = aws.String(strings.Join([]string{baseName, "sum"}, "_"))
 			case statisticTypeCount:
-				datum.MetricName = aws.String(strings.Join([]string{f.metricName, f.fieldName, "count"}, "_"))
+				datum.MetricName = aws.String(strings.Join([]string{baseName, "count"}, "_"))
 			default:
 				// should not be here
 				continue
@@ -128,6 +366,9 @@ type valueField struct {
 	value             float64
 	timestamp         time.Time
 	storageResolution int64
+	unit              types.StandardUnit
+	dimCfg            *dimensionsConfig
+	nameOverride      string
 }
 
 func (f *valueField) addValue(sType statisticType, value float64) {
@@ -137,15 +378,98 @@ func (f *valueField) addValue(sType statisticType, value float64) {
 }
 
 func (f *valueField) buildDatum() []types.MetricDatum {
-	return []types.MetricDatum{
-		{
-			MetricName:        aws.String(strings.Join([]string{f.metricName, f.fieldName}, "_")),
-			Value:             aws.Float64(f.value),
-			Dimensions:        BuildDimensions(f.tags),
+	name := f.nameOverride
+	if name == "" {
+		name = strings.Join([]string{f.metricName, f.fieldName}, "_")
+	}
+
+	datum := types.MetricDatum{
+		MetricName:        aws.String(name),
+		Value:             aws.Float64(f.value),
+		Dimensions:        BuildDimensions(f.tags, f.dimCfg),
+		Timestamp:         aws.Time(f.timestamp),
+		StorageResolution: aws.Int32(int32(f.storageResolution)),
+	}
+	if f.unit != "" {
+		datum.Unit = f.unit
+	}
+
+	return []types.MetricDatum{datum}
+}
+
+// distributionField accumulates raw observations for a field across a flush
+// interval and emits them as CloudWatch MetricDatum.Values/Counts pairs,
+// which lets CloudWatch compute percentile statistics (p50, p90, p99, ...)
+// instead of only min/max/avg. Fields are expected to carry a "_histogram"
+// suffix, e.g. produced by the "histogram" aggregator.
+type distributionField struct {
+	metricName        string
+	fieldName         string
+	tags              map[string]string
+	timestamp         time.Time
+	storageResolution int64
+	unit              types.StandardUnit
+	dimCfg            *dimensionsConfig
+	nameOverride      string
+	namespace         string
+	observations      map[float64]float64 // value -> occurrence count
+}
+
+func (f *distributionField) addValue(sType statisticType, value float64) {
+	if sType != statisticTypeDistribution {
+		return
+	}
+	f.observations[value]++
+}
+
+// CloudWatch accepts at most 150 Values/Counts pairs per MetricDatum, so a
+// distribution with more distinct values than that is split across multiple
+// datums.
+const maxValuesPerDatum = 150
+
+func (f *distributionField) buildDatum() []types.MetricDatum {
+	values := make([]float64, 0, len(f.observations))
+	for v := range f.observations {
+		values = append(values, v)
+	}
+	sort.Float64s(values)
+
+	name := f.nameOverride
+	if name == "" {
+		name = strings.Join([]string{f.metricName, f.fieldName}, "_")
+	}
+
+	var datums []types.MetricDatum
+	for start := 0; start < len(values); start += maxValuesPerDatum {
+		end := start + maxValuesPerDatum
+		if end > len(values) {
+			end = len(values)
+		}
+		chunk := values[start:end]
+
+		datumValues := make([]float64, len(chunk))
+		datumCounts := make([]float64, len(chunk))
+		for i, v := range chunk {
+			datumValues[i] = v
+			datumCounts[i] = f.observations[v]
+		}
+
+		datum := types.MetricDatum{
+			MetricName:        aws.String(name),
+			Dimensions:        BuildDimensions(f.tags, f.dimCfg),
 			Timestamp:         aws.Time(f.timestamp),
+			Values:            datumValues,
+			Counts:            datumCounts,
 			StorageResolution: aws.Int32(int32(f.storageResolution)),
-		},
+		}
+		if f.unit != "" {
+			datum.Unit = f.unit
+		}
+
+		datums = append(datums, datum)
 	}
+
+	return datums
 }
 
 var sampleConfig = `
@@ -187,8 +511,97 @@ var sampleConfig = `
   ## fields are available, all fields would still be sent as raw metrics.
   # write_statistics = false
 
+  ## Send fields with a "_histogram" suffix (e.g. produced by the "histogram"
+  ## aggregator) as CloudWatch distribution metrics using the Values/Counts
+  ## fields of MetricDatum instead of a single value. This gives CloudWatch
+  ## enough information to compute percentile statistics (p50, p90, p99, ...)
+  ## rather than only min/max/avg. CloudWatch allows up to 150 Values/Counts
+  ## pairs per MetricDatum; larger distributions are split across multiple
+  ## datums automatically.
+  # write_distributions = false
+
+  ## Name of a tag whose value is used verbatim as the CloudWatch StandardUnit
+  ## (e.g. "Milliseconds", "Bytes", "Count/Second") for a metric. Takes
+  ## precedence over the "unit" table below.
+  # unit_tag = ""
+
+  ## Per-field CloudWatch StandardUnit, keyed by field name (with any
+  ## "_histogram", "_min", "_max", "_sum" or "_count" suffix stripped). Only
+  ## consulted when "unit_tag" is unset or the tag is missing from a given
+  ## metric. Units are not attached to statistic sets built by write_statistics.
+  # [outputs.cloudwatch.unit]
+  #   latency = "Milliseconds"
+
   ## Enable high resolution metrics of 1 second (if not enabled, standard resolution are of 60 seconds precision)
   # high_resolution_metrics = false
+
+  ## Maximum number of PutMetricData requests to have in flight at once.
+  ## Partitions (batches of up to 20 datums) are dispatched to a worker pool
+  ## of this size instead of being sent serially.
+  # max_concurrent_requests = 5
+
+  ## Maximum number of retries for a throttled or transient PutMetricData
+  ## failure (e.g. ThrottlingException, InternalServiceFault, 5xx, request
+  ## timeouts) before giving up on that partition.
+  # max_retries = 3
+
+  ## Upper bound on the exponential backoff delay between retries.
+  # retry_max_backoff = "5s"
+
+  ## Maximum PutMetricData calls per second. Set this if this plugin shares
+  ## the CloudWatch account-level quota (150 TPS) with other callers.
+  # rate_limit = 0.0
+
+  ## Tags to use as CloudWatch dimensions, in priority order. CloudWatch
+  ## supports at most 10 dimensions per metric; by default the first 10 tags
+  ## alphabetically are used, which can silently drop important tags like
+  ## "region" or "service". Set this to pick dimensions explicitly instead.
+  # dimensions_include = []
+
+  ## Tags that should never become CloudWatch dimensions, regardless of
+  ## dimensions_include.
+  # dimensions_exclude = []
+
+  ## Always include the "host" tag as a dimension ahead of any others. Set to
+  ## false to make "host" subject to the same include/exclude rules as any
+  ## other tag.
+  # dimensions_default_host = true
+
+  ## Transport to use. "putmetricdata" (default) calls the CloudWatch
+  ## PutMetricData API. "emf" instead serializes each metric as CloudWatch
+  ## Embedded Metric Format JSON, useful for Lambda/ECS-FireLens setups whose
+  ## logs are auto-parsed by CloudWatch Logs into metrics.
+  # mode = "putmetricdata"
+
+  ## In "emf" mode, send the JSON to this CloudWatch Logs log group/stream via
+  ## PutLogEvents instead of stdout. Both must be set together.
+  # log_group = ""
+  # log_stream = ""
+
+  ## Per-measurement overrides of namespace, metric name and unit, consulted
+  ## in order (first match wins) so a single plugin instance can route
+  ## different measurements to different CloudWatch namespaces with stable,
+  ## human-readable names instead of "measurement_field".
+  # [[outputs.cloudwatch.metric]]
+  #   ## Glob pattern matched against the measurement name.
+  #   measurement = "http_request"
+  #
+  #   ## CloudWatch namespace to publish matching measurements to. Defaults
+  #   ## to the top-level "namespace" if unset.
+  #   namespace = "MyApp/HTTP"
+  #
+  #   ## Go template rendered with fields .Measurement, .Field and .Tags to
+  #   ## produce the CloudWatch metric name. Defaults to "measurement_field";
+  #   ## in "emf" mode, non-distribution fields default to the field name alone.
+  #   name_template = "RequestLatency"
+  #
+  #   ## CloudWatch StandardUnit override for fields matched by this rule. Not
+  #   ## applied to statistic sets built by write_statistics.
+  #   unit = "Milliseconds"
+  #
+  #   ## Storage resolution override: 1 (high resolution) or 60 (standard).
+  #   ## Defaults to high_resolution_metrics above when unset.
+  #   storage_resolution = 1
 `
 
 func (c *CloudWatch) SampleConfig() string {
@@ -199,13 +612,49 @@ func (c *CloudWatch) Description() string {
 	return "Configuration for AWS CloudWatch output."
 }
 
+// maxAttemptsFor turns the user-facing max_retries (number of retries after
+// the initial try, default 3) into the SDK retryer's MaxAttempts (which
+// counts the initial try too).
+func maxAttemptsFor(maxRetries int) int {
+	if maxRetries <= 0 {
+		maxRetries = 3
+	}
+	return maxRetries + 1
+}
+
 func (c *CloudWatch) Connect() error {
 	cfg, err := c.CredentialConfig.Credentials()
 	if err != nil {
 		return err
 	}
 
-	c.svc = cloudwatch.NewFromConfig(cfg)
+	maxAttempts := maxAttemptsFor(c.MaxRetries)
+	maxBackoff := time.Duration(c.RetryMaxBackoff)
+	if maxBackoff <= 0 {
+		maxBackoff = 5 * time.Second
+	}
+
+	c.svc = cloudwatch.NewFromConfig(cfg, func(o *cloudwatch.Options) {
+		o.Retryer = retry.NewStandard(func(ro *retry.StandardOptions) {
+			ro.MaxAttempts = maxAttempts
+			ro.MaxBackoff = maxBackoff
+		})
+	})
+
+	if c.RateLimit > 0 {
+		c.limiter = rate.NewLimiter(rate.Limit(c.RateLimit), 1)
+	}
+
+	if c.Mode == "emf" && c.LogGroup != "" {
+		c.logsSvc = cloudwatchlogs.NewFromConfig(cfg)
+
+		token, err := c.ensureLogStream()
+		if err != nil {
+			return err
+		}
+		c.sequenceToken = token
+	}
+
 	return nil
 }
 
@@ -214,31 +663,102 @@ func (c *CloudWatch) Close() error {
 }
 
 func (c *CloudWatch) Write(metrics []telegraf.Metric) error {
-	var datums []types.MetricDatum
+	if c.Mode == "emf" {
+		return c.writeEMF(metrics)
+	}
+
+	opts := c.metricDatumOptions()
+
+	// Datums are grouped by resolved namespace, since a [[metric]] rule can
+	// route a measurement to a namespace other than the plugin's default and
+	// PutMetricData's Namespace applies to the whole call, not per datum.
+	byNamespace := make(map[string][]types.MetricDatum)
 	for _, m := range metrics {
-		d := BuildMetricDatum(c.WriteStatistics, c.HighResolutionMetrics, m)
-		datums = append(datums, d...)
+		namespace, d := BuildMetricDatum(opts, m)
+		byNamespace[namespace] = append(byNamespace[namespace], d...)
+	}
+
+	if opts.buildDistribution {
+		// Distributions accumulate raw observations across every metric in
+		// this flush, so they're built once over the whole batch rather than
+		// per point like the other field types above.
+		for namespace, d := range BuildDistributionDatums(opts, metrics) {
+			byNamespace[namespace] = append(byNamespace[namespace], d...)
+		}
 	}
 
 	const maxDatumsPerCall = 20 // PutMetricData only supports up to 20 data metrics per call
 
-	for _, partition := range PartitionDatums(maxDatumsPerCall, datums) {
-		err := c.WriteToCloudWatch(partition)
-		if err != nil {
-			return err
+	type partition struct {
+		namespace string
+		datums    []types.MetricDatum
+	}
+
+	var partitions []partition
+	for namespace, datums := range byNamespace {
+		for _, p := range PartitionDatums(maxDatumsPerCall, datums) {
+			partitions = append(partitions, partition{namespace: namespace, datums: p})
 		}
 	}
 
+	workers := c.MaxConcurrentRequests
+	if workers <= 0 {
+		workers = 5
+	}
+	if workers > len(partitions) {
+		workers = len(partitions)
+	}
+
+	jobs := make(chan partition)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				if err := c.WriteToCloudWatch(p.namespace, p.datums); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, p := range partitions {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to write %d of %d partitions to CloudWatch, first error: %w", len(errs), len(partitions), errs[0])
+	}
+
 	return nil
 }
 
-func (c *CloudWatch) WriteToCloudWatch(datums []types.MetricDatum) error {
+func (c *CloudWatch) WriteToCloudWatch(namespace string, datums []types.MetricDatum) error {
+	ctx := context.Background()
+
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
 	params := &cloudwatch.PutMetricDataInput{
 		MetricData: datums,
-		Namespace:  aws.String(c.Namespace),
+		Namespace:  aws.String(namespace),
 	}
 
-	_, err := c.svc.PutMetricData(context.Background(), params)
+	// Retries for throttling, transient service faults and timeouts are
+	// handled by the retryer configured in Connect; an error here has
+	// already exhausted those retries or is non-retryable.
+	_, err := c.svc.PutMetricData(ctx, params)
 
 	if err != nil {
 		c.Log.Errorf("Unable to write to CloudWatch : %+v", err.Error())
@@ -247,6 +767,277 @@ func (c *CloudWatch) WriteToCloudWatch(datums []types.MetricDatum) error {
 	return err
 }
 
+// writeEMF serializes metrics as CloudWatch Embedded Metric Format JSON and
+// sends them either to stdout (for Lambda/ECS-FireLens, whose logs are
+// auto-parsed by CloudWatch Logs into metrics) or to a CloudWatch Logs log
+// group/stream via PutLogEvents when log_group is configured.
+func (c *CloudWatch) writeEMF(metrics []telegraf.Metric) error {
+	opts := c.metricDatumOptions()
+
+	var events []string
+	for _, m := range metrics {
+		if event, ok := buildEMFEvent(opts, m); ok {
+			events = append(events, event)
+		}
+	}
+
+	if opts.buildDistribution {
+		events = append(events, buildEMFDistributionEvents(opts, metrics)...)
+	}
+
+	if c.LogGroup == "" {
+		for _, event := range events {
+			fmt.Fprintln(os.Stdout, event)
+		}
+		return nil
+	}
+
+	return c.putLogEvents(events)
+}
+
+// buildEMFEvent turns one telegraf.Metric into an EMF JSON log event. Fields
+// with a "_histogram" suffix are skipped here; they're handled in aggregate
+// by buildEMFDistributionEvents.
+func buildEMFEvent(opts metricDatumOptions, point telegraf.Metric) (string, bool) {
+	tags := point.Tags()
+	dims := BuildDimensions(tags, opts.dimensions)
+	rule := opts.ruleFor(point.Name())
+	namespace := opts.namespaceFor(rule)
+
+	dimensionNames := make([]string, len(dims))
+	doc := make(map[string]interface{}, len(dims)+2)
+	for i, d := range dims {
+		dimensionNames[i] = *d.Name
+		doc[*d.Name] = *d.Value
+	}
+
+	var metricsMeta []map[string]interface{}
+	for k, v := range point.Fields() {
+		val, ok := convert(v)
+		if !ok {
+			continue
+		}
+
+		sType, fieldName := getStatisticType(k)
+		if sType == statisticTypeDistribution && opts.buildDistribution {
+			continue
+		}
+
+		name := k
+		if override := opts.metricNameFor(rule, point.Name(), k, tags); override != "" {
+			name = override
+		}
+
+		meta := map[string]interface{}{"Name": name}
+		if u := opts.unitFor(rule, fieldName, tags); u != "" {
+			meta["Unit"] = string(u)
+		}
+		meta["StorageResolution"] = opts.storageResolutionFor(rule)
+		metricsMeta = append(metricsMeta, meta)
+		doc[name] = val
+	}
+
+	if len(metricsMeta) == 0 {
+		return "", false
+	}
+
+	doc["_aws"] = map[string]interface{}{
+		"Timestamp": point.Time().UnixMilli(),
+		"CloudWatchMetrics": []map[string]interface{}{
+			{
+				"Namespace":  namespace,
+				"Dimensions": [][]string{dimensionNames},
+				"Metrics":    metricsMeta,
+			},
+		},
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return "", false
+	}
+
+	return string(b), true
+}
+
+// buildEMFDistributionEvents mirrors BuildDistributionDatums but emits EMF
+// JSON log events for each distribution series, with the observations as a
+// flat array of values split across events of at most maxValuesPerDatum.
+func buildEMFDistributionEvents(opts metricDatumOptions, metrics []telegraf.Metric) []string {
+	distributions := accumulateDistributions(opts, metrics)
+
+	var events []string
+	for _, d := range distributions {
+		values := make([]float64, 0, len(d.observations))
+		for v := range d.observations {
+			values = append(values, v)
+		}
+		sort.Float64s(values)
+
+		// EMF's metric value is a plain number or a flat array of numbers;
+		// unlike PutMetricData it has no paired Values/Counts form, so each
+		// observed value is repeated by its occurrence count.
+		var flatValues []float64
+		for _, v := range values {
+			count := int(d.observations[v])
+			for i := 0; i < count; i++ {
+				flatValues = append(flatValues, v)
+			}
+		}
+
+		metricName := d.nameOverride
+		if metricName == "" {
+			metricName = strings.Join([]string{d.metricName, d.fieldName}, "_")
+		}
+		dims := BuildDimensions(d.tags, d.dimCfg)
+		dimensionNames := make([]string, len(dims))
+		for i, dim := range dims {
+			dimensionNames[i] = *dim.Name
+		}
+
+		meta := map[string]interface{}{"Name": metricName}
+		if d.unit != "" {
+			meta["Unit"] = string(d.unit)
+		}
+		meta["StorageResolution"] = d.storageResolution
+
+		// A _histogram field with many distinct values in one flush can
+		// produce a flat array large enough to blow past CloudWatch Logs'
+		// per-event size limit, so it's split the same way buildDatum splits
+		// PutMetricData's Values/Counts at maxValuesPerDatum.
+		for start := 0; start < len(flatValues); start += maxValuesPerDatum {
+			end := start + maxValuesPerDatum
+			if end > len(flatValues) {
+				end = len(flatValues)
+			}
+
+			doc := make(map[string]interface{}, len(dims)+2)
+			for _, dim := range dims {
+				doc[*dim.Name] = *dim.Value
+			}
+			doc["_aws"] = map[string]interface{}{
+				"Timestamp": d.timestamp.UnixMilli(),
+				"CloudWatchMetrics": []map[string]interface{}{
+					{
+						"Namespace":  d.namespace,
+						"Dimensions": [][]string{dimensionNames},
+						"Metrics":    []map[string]interface{}{meta},
+					},
+				},
+			}
+			doc[metricName] = flatValues[start:end]
+
+			b, err := json.Marshal(doc)
+			if err != nil {
+				continue
+			}
+			events = append(events, string(b))
+		}
+	}
+
+	return events
+}
+
+// ensureLogStream looks up the upload sequence token for LogStream, creating
+// it if it doesn't exist yet. A nil token is valid for a brand new stream's
+// first PutLogEvents call.
+func (c *CloudWatch) ensureLogStream() (*string, error) {
+	out, err := c.logsSvc.DescribeLogStreams(context.Background(), &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName:        aws.String(c.LogGroup),
+		LogStreamNamePrefix: aws.String(c.LogStream),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range out.LogStreams {
+		if s.LogStreamName != nil && *s.LogStreamName == c.LogStream {
+			return s.UploadSequenceToken, nil
+		}
+	}
+
+	_, err = c.logsSvc.CreateLogStream(context.Background(), &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(c.LogGroup),
+		LogStreamName: aws.String(c.LogStream),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// CloudWatch Logs PutLogEvents limits: at most 10,000 events or 1MB per call,
+// with each event counted as its UTF-8 byte length plus 26 bytes of overhead.
+const (
+	maxLogEventsPerBatch  = 10000
+	maxLogBatchBytes      = 1 << 20
+	logEventOverheadBytes = 26
+)
+
+func (c *CloudWatch) putLogEvents(events []string) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var batch []string
+	batchBytes := 0
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := c.putLogEventBatch(batch)
+		batch = nil
+		batchBytes = 0
+		return err
+	}
+
+	for _, event := range events {
+		eventBytes := len(event) + logEventOverheadBytes
+		if len(batch) >= maxLogEventsPerBatch || batchBytes+eventBytes > maxLogBatchBytes {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		batch = append(batch, event)
+		batchBytes += eventBytes
+	}
+
+	return flush()
+}
+
+func (c *CloudWatch) putLogEventBatch(events []string) error {
+	logEvents := make([]cwltypes.InputLogEvent, len(events))
+	now := aws.Int64(time.Now().UnixMilli())
+	for i, event := range events {
+		logEvents[i] = cwltypes.InputLogEvent{
+			Message:   aws.String(event),
+			Timestamp: now,
+		}
+	}
+
+	params := &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(c.LogGroup),
+		LogStreamName: aws.String(c.LogStream),
+		LogEvents:     logEvents,
+		SequenceToken: c.sequenceToken,
+	}
+
+	resp, err := c.logsSvc.PutLogEvents(context.Background(), params)
+	if err != nil {
+		var invalidToken *cwltypes.InvalidSequenceTokenException
+		if errors.As(err, &invalidToken) {
+			c.sequenceToken = invalidToken.ExpectedSequenceToken
+			return c.putLogEventBatch(events)
+		}
+		return err
+	}
+
+	c.sequenceToken = resp.NextSequenceToken
+	return nil
+}
+
 // Partition the MetricDatums into smaller slices of a max size so that are under the limit
 // for the AWS API calls.
 func PartitionDatums(size int, datums []types.MetricDatum) [][]types.MetricDatum {
@@ -270,16 +1061,23 @@ func PartitionDatums(size int, datums []types.MetricDatum) [][]types.MetricDatum
 	return partitions
 }
 
-// Make a MetricDatum from telegraf.Metric. It would check if all required fields of
-// cloudwatch.StatisticSet are available. If so, it would build MetricDatum from statistic values.
-// Otherwise, fields would still been built independently.
-func BuildMetricDatum(buildStatistic bool, highResolutionMetrics bool, point telegraf.Metric) []types.MetricDatum {
+// BuildMetricDatum makes MetricDatums from telegraf.Metric, along with the
+// CloudWatch namespace they should be published to (honoring any matching
+// [[metric]] rule's Namespace override). It would check if all required
+// fields of cloudwatch.StatisticSet are available. If so, it would build
+// MetricDatum from statistic values. Otherwise, fields would still been built
+// independently.
+//
+// Fields with a "_histogram" suffix are distribution observations: when
+// buildDistribution is enabled they are skipped here and instead collected by
+// BuildDistributionDatums, which accumulates them across every point in a
+// flush rather than just this one.
+func BuildMetricDatum(opts metricDatumOptions, point telegraf.Metric) (string, []types.MetricDatum) {
 	fields := make(map[string]cloudwatchField)
 	tags := point.Tags()
-	storageResolution := int64(60)
-	if highResolutionMetrics {
-		storageResolution = 1
-	}
+	rule := opts.ruleFor(point.Name())
+	namespace := opts.namespaceFor(rule)
+	storageResolution := opts.storageResolutionFor(rule)
 
 	for k, v := range point.Fields() {
 		val, ok := convert(v)
@@ -291,8 +1089,12 @@ func BuildMetricDatum(buildStatistic bool, highResolutionMetrics bool, point tel
 
 		sType, fieldName := getStatisticType(k)
 
+		if sType == statisticTypeDistribution && opts.buildDistribution {
+			continue
+		}
+
 		// If statistic metric is not enabled or non-statistic type, just take current field as a value field.
-		if !buildStatistic || sType == statisticTypeNone {
+		if !opts.buildStatistic || sType == statisticTypeNone || sType == statisticTypeDistribution {
 			fields[k] = &valueField{
 				metricName:        point.Name(),
 				fieldName:         k,
@@ -300,6 +1102,9 @@ func BuildMetricDatum(buildStatistic bool, highResolutionMetrics bool, point tel
 				timestamp:         point.Time(),
 				value:             val,
 				storageResolution: storageResolution,
+				unit:              opts.unitFor(rule, fieldName, tags),
+				dimCfg:            opts.dimensions,
+				nameOverride:      opts.metricNameFor(rule, point.Name(), k, tags),
 			}
 			continue
 		}
@@ -316,6 +1121,8 @@ func BuildMetricDatum(buildStatistic bool, highResolutionMetrics bool, point tel
 					sType: val,
 				},
 				storageResolution: storageResolution,
+				dimCfg:            opts.dimensions,
+				nameOverride:      opts.metricNameFor(rule, point.Name(), fieldName, tags),
 			}
 		} else {
 			// Add new statistic value to this field
@@ -329,46 +1136,166 @@ func BuildMetricDatum(buildStatistic bool, highResolutionMetrics bool, point tel
 		datums = append(datums, d...)
 	}
 
-	return datums
+	return namespace, datums
 }
 
-// Make a list of Dimensions by using a Point's tags. CloudWatch supports up to
-// 10 dimensions per metric so we only keep up to the first 10 alphabetically.
-// This always includes the "host" tag if it exists.
-func BuildDimensions(mTags map[string]string) []types.Dimension {
-	const MaxDimensions = 10
-	dimensions := make([]types.Dimension, 0, MaxDimensions)
-
-	// This is pretty ugly but we always want to include the "host" tag if it exists.
-	if host, ok := mTags["host"]; ok {
-		dimensions = append(dimensions, types.Dimension{
-			Name:  aws.String("host"),
-			Value: aws.String(host),
-		})
+// BuildDistributionDatums accumulates every "_histogram"-suffixed field
+// across all of the given metrics and turns each into one or more
+// MetricDatum carrying Values/Counts, splitting at CloudWatch's 150-pair
+// limit per datum, grouped by the CloudWatch namespace they should be
+// published to.
+func BuildDistributionDatums(opts metricDatumOptions, metrics []telegraf.Metric) map[string][]types.MetricDatum {
+	distributions := accumulateDistributions(opts, metrics)
+
+	datums := make(map[string][]types.MetricDatum)
+	for _, d := range distributions {
+		datums[d.namespace] = append(datums[d.namespace], d.buildDatum()...)
 	}
 
-	var keys []string
-	for k := range mTags {
-		if k != "host" {
-			keys = append(keys, k)
+	return datums
+}
+
+// accumulateDistributions merges every "_histogram"-suffixed field across all
+// of the given metrics into one distributionField per (measurement, field,
+// dimensions) series. Shared by BuildDistributionDatums and the EMF writer.
+func accumulateDistributions(opts metricDatumOptions, metrics []telegraf.Metric) map[string]*distributionField {
+	distributions := make(map[string]*distributionField)
+	for _, point := range metrics {
+		tags := point.Tags()
+		rule := opts.ruleFor(point.Name())
+		namespace := opts.namespaceFor(rule)
+		storageResolution := opts.storageResolutionFor(rule)
+
+		for k, v := range point.Fields() {
+			sType, fieldName := getStatisticType(k)
+			if sType != statisticTypeDistribution {
+				continue
+			}
+
+			val, ok := convert(v)
+			if !ok {
+				continue
+			}
+
+			key := distributionKey(point.Name(), fieldName, tags)
+			d, ok := distributions[key]
+			if !ok {
+				d = &distributionField{
+					metricName:        point.Name(),
+					fieldName:         fieldName,
+					tags:              tags,
+					timestamp:         point.Time(),
+					storageResolution: storageResolution,
+					unit:              opts.unitFor(rule, fieldName, tags),
+					dimCfg:            opts.dimensions,
+					nameOverride:      opts.metricNameFor(rule, point.Name(), fieldName, tags),
+					namespace:         namespace,
+					observations:      make(map[float64]float64),
+				}
+				distributions[key] = d
+			}
+			d.addValue(sType, val)
 		}
 	}
+
+	return distributions
+}
+
+// distributionKey identifies a distinct distribution series within a flush:
+// same measurement, same field, same dimensions.
+func distributionKey(metricName, fieldName string, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
 	sort.Strings(keys)
 
+	var b strings.Builder
+	b.WriteString(metricName)
+	b.WriteByte('\x00')
+	b.WriteString(fieldName)
 	for _, k := range keys {
-		if len(dimensions) >= MaxDimensions {
-			break
+		b.WriteByte('\x00')
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+	}
+
+	return b.String()
+}
+
+// fieldUnit resolves the CloudWatch StandardUnit for a field, preferring the
+// unitTag tag value and falling back to the per-field units table.
+func fieldUnit(unitTag string, units map[string]string, fieldName string, tags map[string]string) types.StandardUnit {
+	if unitTag != "" {
+		if u, ok := tags[unitTag]; ok && u != "" {
+			return types.StandardUnit(u)
 		}
+	}
 
-		value := mTags[k]
-		if value == "" {
-			continue
+	if u, ok := units[fieldName]; ok {
+		return types.StandardUnit(u)
+	}
+
+	return ""
+}
+
+// Make a list of Dimensions by using a Point's tags. CloudWatch supports up to
+// 10 dimensions per metric. If cfg.include is set, tags are chosen in that
+// priority order; otherwise the first 10 tags alphabetically are used, as
+// before. cfg.exclude is consulted either way, and the "host" tag is always
+// included first unless cfg.defaultHost is false.
+func BuildDimensions(mTags map[string]string, cfg *dimensionsConfig) []types.Dimension {
+	const maxDimensions = 10
+	dimensions := make([]types.Dimension, 0, maxDimensions)
+	added := make(map[string]bool, maxDimensions)
+
+	addDimension := func(name string) {
+		if added[name] || cfg.exclude[name] {
+			return
+		}
+
+		value, ok := mTags[name]
+		if !ok || value == "" {
+			return
+		}
+
+		if len(dimensions) >= maxDimensions {
+			if cfg.log != nil {
+				cfg.log.Debugf("dropping tag %q, already have the maximum of %d CloudWatch dimensions", name, maxDimensions)
+			}
+			return
 		}
 
 		dimensions = append(dimensions, types.Dimension{
-			Name:  aws.String(k),
-			Value: aws.String(mTags[k]),
+			Name:  aws.String(name),
+			Value: aws.String(value),
 		})
+		added[name] = true
+	}
+
+	if cfg.defaultHost {
+		addDimension("host")
+	}
+
+	if len(cfg.include) > 0 {
+		for _, k := range cfg.include {
+			addDimension(k)
+		}
+		return dimensions
+	}
+
+	var keys []string
+	for k := range mTags {
+		if added[k] {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		addDimension(k)
 	}
 
 	return dimensions
@@ -388,6 +1315,9 @@ func getStatisticType(name string) (sType statisticType, fieldName string) {
 	case strings.HasSuffix(name, "_count"):
 		sType = statisticTypeCount
 		fieldName = strings.TrimSuffix(name, "_count")
+	case strings.HasSuffix(name, "_histogram"):
+		sType = statisticTypeDistribution
+		fieldName = strings.TrimSuffix(name, "_histogram")
 	default:
 		sType = statisticTypeNone
 		fieldName = name
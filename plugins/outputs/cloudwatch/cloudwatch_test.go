@@ -0,0 +1,497 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// mustMetric builds a telegraf.Metric for tests, failing immediately on
+// construction errors so test bodies can stay focused on behavior.
+func mustMetric(t *testing.T, name string, tags map[string]string, fields map[string]interface{}) telegraf.Metric {
+	t.Helper()
+	m, err := metric.New(name, tags, fields, time.Unix(0, 0))
+	require.NoError(t, err)
+	return m
+}
+
+func TestDistributionFieldBuildDatumSplitsAtMaxValuesPerDatum(t *testing.T) {
+	f := &distributionField{
+		metricName:   "http_request",
+		fieldName:    "latency",
+		tags:         map[string]string{},
+		timestamp:    time.Unix(0, 0),
+		observations: make(map[float64]float64),
+	}
+
+	// One more distinct value than fits in a single datum.
+	for i := 0; i < maxValuesPerDatum+1; i++ {
+		f.addValue(statisticTypeDistribution, float64(i))
+	}
+
+	datums := f.buildDatum()
+	require.Len(t, datums, 2)
+	require.Len(t, datums[0].Values, maxValuesPerDatum)
+	require.Len(t, datums[0].Counts, maxValuesPerDatum)
+	require.Len(t, datums[1].Values, 1)
+	require.Len(t, datums[1].Counts, 1)
+
+	total := len(datums[0].Values) + len(datums[1].Values)
+	require.Equal(t, maxValuesPerDatum+1, total)
+}
+
+func TestDistributionFieldBuildDatumCountsRepeatedObservations(t *testing.T) {
+	f := &distributionField{
+		metricName:   "http_request",
+		fieldName:    "latency",
+		tags:         map[string]string{},
+		timestamp:    time.Unix(0, 0),
+		observations: make(map[float64]float64),
+	}
+
+	f.addValue(statisticTypeDistribution, 42)
+	f.addValue(statisticTypeDistribution, 42)
+	f.addValue(statisticTypeDistribution, 7)
+
+	datums := f.buildDatum()
+	require.Len(t, datums, 1)
+	require.Equal(t, []float64{7, 42}, datums[0].Values)
+	require.Equal(t, []float64{1, 2}, datums[0].Counts)
+}
+
+func TestDistributionKeyIsStableAndDistinguishesTags(t *testing.T) {
+	a := distributionKey("http_request", "latency", map[string]string{"region": "us-east-1", "host": "a"})
+	b := distributionKey("http_request", "latency", map[string]string{"host": "a", "region": "us-east-1"})
+	require.Equal(t, a, b, "key must not depend on map iteration order")
+
+	c := distributionKey("http_request", "latency", map[string]string{"region": "us-west-2", "host": "a"})
+	require.NotEqual(t, a, c, "different tag values must not collide")
+
+	d := distributionKey("http_request", "throughput", map[string]string{"region": "us-east-1", "host": "a"})
+	require.NotEqual(t, a, d, "different field names must not collide")
+}
+
+func TestAccumulateDistributionsMergesAcrossMetrics(t *testing.T) {
+	opts := metricDatumOptions{
+		buildDistribution: true,
+		dimensions:        &dimensionsConfig{defaultHost: true},
+		namespace:         "Test",
+	}
+
+	m1 := mustMetric(t, "http_request", map[string]string{"host": "a"}, map[string]interface{}{"latency_histogram": 1.0})
+	m2 := mustMetric(t, "http_request", map[string]string{"host": "a"}, map[string]interface{}{"latency_histogram": 2.0})
+
+	distributions := accumulateDistributions(opts, []telegraf.Metric{m1, m2})
+	require.Len(t, distributions, 1)
+
+	for _, d := range distributions {
+		require.Equal(t, map[float64]float64{1: 1, 2: 1}, d.observations)
+	}
+}
+
+func TestMaxAttemptsForCountsInitialTryPlusRetries(t *testing.T) {
+	require.Equal(t, 4, maxAttemptsFor(3), "default 3 retries must yield 4 total attempts")
+	require.Equal(t, 6, maxAttemptsFor(5))
+	require.Equal(t, 4, maxAttemptsFor(0), "non-positive retries fall back to the default of 3")
+	require.Equal(t, 4, maxAttemptsFor(-1))
+}
+
+func TestPartitionDatumsSplitsIntoSizedChunks(t *testing.T) {
+	datums := make([]types.MetricDatum, 45)
+
+	partitions := PartitionDatums(20, datums)
+	require.Len(t, partitions, 3)
+	require.Len(t, partitions[0], 20)
+	require.Len(t, partitions[1], 20)
+	require.Len(t, partitions[2], 5)
+}
+
+// fakePutMetricData is a cloudWatchAPI that records calls and fails for
+// namespaces listed in failFor, letting tests exercise Write's worker pool
+// and error aggregation without a real AWS connection.
+type fakePutMetricData struct {
+	mu      sync.Mutex
+	calls   int
+	failFor map[string]bool
+}
+
+func (f *fakePutMetricData) PutMetricData(_ context.Context, params *cloudwatch.PutMetricDataInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+
+	if f.failFor[*params.Namespace] {
+		return nil, errors.New("simulated PutMetricData failure")
+	}
+	return &cloudwatch.PutMetricDataOutput{}, nil
+}
+
+func TestWriteDispatchesAllPartitionsThroughWorkerPool(t *testing.T) {
+	fake := &fakePutMetricData{}
+	c := &CloudWatch{
+		Namespace:             "Test",
+		MaxConcurrentRequests: 2,
+		svc:                   fake,
+		Log:                   testLogger{},
+	}
+
+	var metrics []telegraf.Metric
+	for i := 0; i < 50; i++ {
+		metrics = append(metrics, mustMetric(t, "http_request", map[string]string{"host": "a"}, map[string]interface{}{"value": float64(i)}))
+	}
+
+	require.NoError(t, c.Write(metrics))
+	// 50 one-field metrics each produce one datum; 20 per PutMetricData call.
+	require.Equal(t, 3, fake.calls)
+}
+
+func TestWriteAggregatesErrorsAcrossNamespaces(t *testing.T) {
+	fake := &fakePutMetricData{failFor: map[string]bool{"Bad": true}}
+	c := &CloudWatch{
+		Namespace: "Good",
+		Metrics: []*MetricConfig{
+			{Measurement: "fails", Namespace: "Bad"},
+		},
+		svc: fake,
+		Log: testLogger{},
+	}
+	require.NoError(t, c.Init())
+
+	metrics := []telegraf.Metric{
+		mustMetric(t, "ok", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}),
+		mustMetric(t, "fails", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}),
+	}
+
+	err := c.Write(metrics)
+	require.Error(t, err)
+}
+
+func TestWriteRespectsZeroWorkPool(t *testing.T) {
+	fake := &fakePutMetricData{}
+	c := &CloudWatch{Namespace: "Test", svc: fake, Log: testLogger{}}
+
+	require.NoError(t, c.Write(nil))
+	require.Equal(t, 0, fake.calls)
+}
+
+// testLogger is a minimal telegraf.Logger for tests that don't care about
+// log output but exercise code paths that call through c.Log.
+type testLogger struct{}
+
+func (testLogger) Errorf(string, ...interface{}) {}
+func (testLogger) Error(...interface{})          {}
+func (testLogger) Debugf(string, ...interface{}) {}
+func (testLogger) Debug(...interface{})          {}
+func (testLogger) Warnf(string, ...interface{})  {}
+func (testLogger) Warn(...interface{})           {}
+func (testLogger) Infof(string, ...interface{})  {}
+func (testLogger) Info(...interface{})           {}
+
+// recordingLogger captures Debugf calls so tests can assert truncation is
+// logged without caring about the exact message text.
+type recordingLogger struct {
+	testLogger
+	debugfCalls int
+}
+
+func (l *recordingLogger) Debugf(string, ...interface{}) {
+	l.debugfCalls++
+}
+
+func TestBuildDimensions(t *testing.T) {
+	tags := map[string]string{
+		"host":    "server01",
+		"region":  "us-east-1",
+		"service": "api",
+		"az":      "a",
+	}
+
+	tests := []struct {
+		name   string
+		cfg    *dimensionsConfig
+		expect []string
+	}{
+		{
+			name:   "default is first 10 alphabetically with host forced first",
+			cfg:    &dimensionsConfig{defaultHost: true},
+			expect: []string{"host", "az", "region", "service"},
+		},
+		{
+			name:   "defaultHost false subjects host to alphabetical ordering",
+			cfg:    &dimensionsConfig{defaultHost: false},
+			expect: []string{"az", "host", "region", "service"},
+		},
+		{
+			name:   "include honors priority order over alphabetical",
+			cfg:    &dimensionsConfig{include: []string{"service", "region"}, defaultHost: true},
+			expect: []string{"host", "service", "region"},
+		},
+		{
+			name:   "include without defaultHost only uses the allowlist",
+			cfg:    &dimensionsConfig{include: []string{"service", "region"}, defaultHost: false},
+			expect: []string{"service", "region"},
+		},
+		{
+			name:   "exclude drops tags from the alphabetical default",
+			cfg:    &dimensionsConfig{exclude: map[string]bool{"region": true}, defaultHost: true},
+			expect: []string{"host", "az", "service"},
+		},
+		{
+			name:   "missing include entries are skipped, not zero-valued",
+			cfg:    &dimensionsConfig{include: []string{"service", "missing", "region"}, defaultHost: false},
+			expect: []string{"service", "region"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dims := BuildDimensions(tags, tt.cfg)
+
+			var names []string
+			for _, d := range dims {
+				names = append(names, *d.Name)
+			}
+			require.Equal(t, tt.expect, names)
+		})
+	}
+}
+
+func TestBuildDimensionsEmptyValuesAreSkipped(t *testing.T) {
+	tags := map[string]string{"host": "server01", "region": ""}
+	cfg := &dimensionsConfig{defaultHost: true}
+
+	dims := BuildDimensions(tags, cfg)
+	require.Len(t, dims, 1)
+	require.Equal(t, "host", *dims[0].Name)
+}
+
+func TestBuildDimensionsLogsWhenTruncated(t *testing.T) {
+	tags := make(map[string]string, 11)
+	for i := 0; i < 11; i++ {
+		tags[string(rune('a'+i))] = "v"
+	}
+
+	log := &recordingLogger{}
+	cfg := &dimensionsConfig{defaultHost: false, log: log}
+
+	dims := BuildDimensions(tags, cfg)
+	require.Len(t, dims, 10)
+	require.Equal(t, 1, log.debugfCalls, "the 11th tag should be logged as dropped")
+}
+
+func TestBuildEMFEvent(t *testing.T) {
+	opts := metricDatumOptions{
+		dimensions: &dimensionsConfig{defaultHost: true},
+		namespace:  "Test",
+		units:      map[string]string{"latency": "Milliseconds"},
+	}
+	m := mustMetric(t, "http_request", map[string]string{"host": "a"}, map[string]interface{}{"latency": 12.5})
+
+	raw, ok := buildEMFEvent(opts, m)
+	require.True(t, ok)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(raw), &doc))
+
+	require.Equal(t, 12.5, doc["latency"])
+	require.Equal(t, "a", doc["host"])
+
+	aws, ok := doc["_aws"].(map[string]interface{})
+	require.True(t, ok)
+	cwMetrics := aws["CloudWatchMetrics"].([]interface{})
+	require.Len(t, cwMetrics, 1)
+	entry := cwMetrics[0].(map[string]interface{})
+	require.Equal(t, "Test", entry["Namespace"])
+
+	metrics := entry["Metrics"].([]interface{})
+	require.Len(t, metrics, 1)
+	meta := metrics[0].(map[string]interface{})
+	require.Equal(t, "latency", meta["Name"])
+	require.Equal(t, "Milliseconds", meta["Unit"])
+	require.Equal(t, float64(60), meta["StorageResolution"])
+}
+
+func TestBuildEMFEventSkipsHistogramFieldsWhenDistributionsAreBuilt(t *testing.T) {
+	opts := metricDatumOptions{buildDistribution: true, dimensions: &dimensionsConfig{defaultHost: true}, namespace: "Test"}
+	m := mustMetric(t, "http_request", map[string]string{"host": "a"}, map[string]interface{}{"latency_histogram": 12.5})
+
+	_, ok := buildEMFEvent(opts, m)
+	require.False(t, ok, "with write_distributions on, the histogram field is emitted by buildEMFDistributionEvents instead")
+}
+
+func TestBuildEMFEventFallsBackToPlainValueWhenDistributionsAreOff(t *testing.T) {
+	opts := metricDatumOptions{dimensions: &dimensionsConfig{defaultHost: true}, namespace: "Test"}
+	m := mustMetric(t, "http_request", map[string]string{"host": "a"}, map[string]interface{}{"latency_histogram": 12.5})
+
+	raw, ok := buildEMFEvent(opts, m)
+	require.True(t, ok, "without write_distributions, a _histogram field must still be emitted as a plain value like putmetricdata mode does")
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(raw), &doc))
+	require.Equal(t, 12.5, doc["latency_histogram"])
+}
+
+func TestBuildEMFDistributionEventsEmitsFlatValueArray(t *testing.T) {
+	opts := metricDatumOptions{
+		buildDistribution: true,
+		dimensions:        &dimensionsConfig{defaultHost: true},
+		namespace:         "Test",
+		highResolution:    true,
+	}
+
+	m1 := mustMetric(t, "http_request", map[string]string{"host": "a"}, map[string]interface{}{"latency_histogram": 1.0})
+	m2 := mustMetric(t, "http_request", map[string]string{"host": "a"}, map[string]interface{}{"latency_histogram": 1.0})
+	m3 := mustMetric(t, "http_request", map[string]string{"host": "a"}, map[string]interface{}{"latency_histogram": 2.0})
+
+	events := buildEMFDistributionEvents(opts, []telegraf.Metric{m1, m2, m3})
+	require.Len(t, events, 1)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(events[0]), &doc))
+
+	// EMF has no Values/Counts pairing; the metric value must be a flat
+	// array with repeated observations, not {"Values":..., "Counts":...}.
+	metricValue, ok := doc["http_request_latency"].([]interface{})
+	require.True(t, ok, "metric value must be a flat JSON array, not an object")
+	require.ElementsMatch(t, []interface{}{1.0, 1.0, 2.0}, metricValue)
+
+	aws := doc["_aws"].(map[string]interface{})
+	entry := aws["CloudWatchMetrics"].([]interface{})[0].(map[string]interface{})
+	meta := entry["Metrics"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, float64(1), meta["StorageResolution"])
+}
+
+func TestBuildEMFDistributionEventsSplitsAtMaxValuesPerDatum(t *testing.T) {
+	opts := metricDatumOptions{
+		buildDistribution: true,
+		dimensions:        &dimensionsConfig{defaultHost: true},
+		namespace:         "Test",
+	}
+
+	// One more observation than fits in a single EMF event.
+	metrics := make([]telegraf.Metric, 0, maxValuesPerDatum+1)
+	for i := 0; i < maxValuesPerDatum+1; i++ {
+		metrics = append(metrics, mustMetric(t, "http_request", map[string]string{"host": "a"}, map[string]interface{}{"latency_histogram": float64(i)}))
+	}
+
+	events := buildEMFDistributionEvents(opts, metrics)
+	require.Len(t, events, 2, "a distribution larger than maxValuesPerDatum must be split across EMF events")
+
+	total := 0
+	for _, event := range events {
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(event), &doc))
+		values := doc["http_request_latency"].([]interface{})
+		require.LessOrEqual(t, len(values), maxValuesPerDatum)
+		total += len(values)
+	}
+	require.Equal(t, maxValuesPerDatum+1, total)
+}
+
+func newCompiledMetricConfig(t *testing.T, m *MetricConfig) *MetricConfig {
+	t.Helper()
+	c := &CloudWatch{Metrics: []*MetricConfig{m}}
+	require.NoError(t, c.Init())
+	return m
+}
+
+func TestMetricConfigRuleResolution(t *testing.T) {
+	rule := newCompiledMetricConfig(t, &MetricConfig{
+		Measurement:       "http_*",
+		Namespace:         "MyApp/HTTP",
+		NameTemplate:      "{{.Measurement}}_{{.Field}}",
+		Unit:              "Milliseconds",
+		StorageResolution: 1,
+	})
+	opts := metricDatumOptions{namespace: "Default", metricRules: []*MetricConfig{rule}}
+
+	require.Same(t, rule, opts.ruleFor("http_request"))
+	require.Nil(t, opts.ruleFor("db_query"), "non-matching measurement must fall back to no rule")
+
+	require.Equal(t, "MyApp/HTTP", opts.namespaceFor(opts.ruleFor("http_request")))
+	require.Equal(t, "Default", opts.namespaceFor(opts.ruleFor("db_query")), "no rule falls back to the plugin-wide namespace")
+
+	require.Equal(t, types.StandardUnit("Milliseconds"), opts.unitFor(rule, "latency", nil))
+	require.Equal(t, int64(1), opts.storageResolutionFor(rule))
+	require.Equal(t, int64(60), opts.storageResolutionFor(nil), "no rule and no high_resolution_metrics defaults to standard resolution")
+
+	name := opts.metricNameFor(rule, "http_request", "latency", nil)
+	require.Equal(t, "http_request_latency", name)
+}
+
+func TestMetricConfigFirstMatchWins(t *testing.T) {
+	first := newCompiledMetricConfig(t, &MetricConfig{Measurement: "http_*", Namespace: "First"})
+	second := newCompiledMetricConfig(t, &MetricConfig{Measurement: "http_request", Namespace: "Second"})
+	opts := metricDatumOptions{metricRules: []*MetricConfig{first, second}}
+
+	require.Equal(t, "First", opts.namespaceFor(opts.ruleFor("http_request")))
+}
+
+func TestMetricConfigUnitFallsBackWhenUnset(t *testing.T) {
+	rule := newCompiledMetricConfig(t, &MetricConfig{Measurement: "http_*"})
+	opts := metricDatumOptions{units: map[string]string{"latency": "Milliseconds"}, metricRules: []*MetricConfig{rule}}
+
+	require.Equal(t, types.StandardUnit("Milliseconds"), opts.unitFor(rule, "latency", nil))
+}
+
+func TestBuildMetricDatumAppliesMatchingRule(t *testing.T) {
+	rule := newCompiledMetricConfig(t, &MetricConfig{
+		Measurement:       "http_request",
+		Namespace:         "MyApp/HTTP",
+		NameTemplate:      "RequestLatency",
+		Unit:              "Milliseconds",
+		StorageResolution: 1,
+	})
+	opts := metricDatumOptions{
+		namespace:   "Default",
+		dimensions:  &dimensionsConfig{defaultHost: true},
+		metricRules: []*MetricConfig{rule},
+	}
+
+	m := mustMetric(t, "http_request", map[string]string{"host": "a"}, map[string]interface{}{"latency": 42.0})
+
+	namespace, datums := BuildMetricDatum(opts, m)
+	require.Equal(t, "MyApp/HTTP", namespace)
+	require.Len(t, datums, 1)
+	require.Equal(t, "RequestLatency", *datums[0].MetricName)
+	require.Equal(t, types.StandardUnit("Milliseconds"), datums[0].Unit)
+	require.Equal(t, int32(1), *datums[0].StorageResolution)
+}
+
+func TestBuildMetricDatumDefaultsWhenNoRuleMatches(t *testing.T) {
+	opts := metricDatumOptions{namespace: "Default", dimensions: &dimensionsConfig{defaultHost: true}}
+	m := mustMetric(t, "db_query", map[string]string{"host": "a"}, map[string]interface{}{"duration": 42.0})
+
+	namespace, datums := BuildMetricDatum(opts, m)
+	require.Equal(t, "Default", namespace)
+	require.Len(t, datums, 1)
+	require.Equal(t, "db_query_duration", *datums[0].MetricName)
+}
+
+func TestBuildMetricDatumUsesStrippedFieldNameForHistogramFallbackUnit(t *testing.T) {
+	// With write_distributions off, a "_histogram" field falls back to a
+	// plain valueField; its unit must still be keyed by the stripped field
+	// name ("latency"), per the documented "unit" table convention, not the
+	// raw suffixed field key ("latency_histogram").
+	opts := metricDatumOptions{
+		namespace:  "Default",
+		dimensions: &dimensionsConfig{defaultHost: true},
+		units:      map[string]string{"latency": "Milliseconds"},
+	}
+	m := mustMetric(t, "http_request", map[string]string{"host": "a"}, map[string]interface{}{"latency_histogram": 42.0})
+
+	_, datums := BuildMetricDatum(opts, m)
+	require.Len(t, datums, 1)
+	require.Equal(t, types.StandardUnit("Milliseconds"), datums[0].Unit)
+}